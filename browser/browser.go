@@ -0,0 +1,124 @@
+// Package browser provides pluggable backends for obtaining the SSO
+// token cookie: a Playwright-driven embedded browser (Firefox, Chromium,
+// or WebKit) or the user's own system browser, coordinated over a
+// short-lived local callback server.
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mxschmitt/playwright-go"
+	"github.com/tvandinther/go-openconnect-sso/config"
+	"github.com/tvandinther/go-openconnect-sso/internal/auth"
+)
+
+// Backend obtains the SSO token cookie for an initialization response,
+// driving whichever browser it wraps to completion.
+type Backend interface {
+	// CallbackURL is the loopback URL this backend wants registered with
+	// the server as part of the init request, or "" if it doesn't need
+	// one (the embedded Playwright backends read the cookie jar directly
+	// instead).
+	CallbackURL() string
+	// Login drives the browser to initResp.LoginURL and returns once the
+	// token cookie has been obtained, or ctx is done.
+	Login(ctx context.Context, initResp config.InitializationResponse, mode auth.Mode, creds auth.Credentials) (string, error)
+	// Close releases any resources (browser process, callback server)
+	// held by the backend.
+	Close() error
+}
+
+// New returns the Backend for the given --browser value: firefox,
+// chromium, webkit, or system.
+func New(name string) (Backend, error) {
+	switch name {
+	case "firefox", "chromium", "webkit":
+		return &playwrightBackend{browserType: name}, nil
+	case "system":
+		return newSystemBackend()
+	default:
+		return nil, fmt.Errorf("unknown browser %q: must be one of firefox, chromium, webkit, system", name)
+	}
+}
+
+// playwrightBackend drives an embedded, Playwright-controlled browser.
+type playwrightBackend struct {
+	browserType string
+
+	pw      *playwright.Playwright
+	browser playwright.Browser
+}
+
+func (b *playwrightBackend) CallbackURL() string { return "" }
+
+func (b *playwrightBackend) Login(ctx context.Context, initResp config.InitializationResponse, mode auth.Mode, creds auth.Credentials) (string, error) {
+	handler, err := auth.NewHandler(mode)
+	if err != nil {
+		return "", err
+	}
+
+	pw, err := playwright.Run()
+	if err != nil {
+		return "", fmt.Errorf("could not launch playwright: %w", err)
+	}
+	b.pw = pw
+
+	launcher, err := b.launcher()
+	if err != nil {
+		return "", err
+	}
+	browser, err := launcher(playwright.BrowserTypeLaunchOptions{
+		Headless: playwright.Bool(mode != auth.ModeWebview),
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not launch %s: %w", b.browserType, err)
+	}
+	b.browser = browser
+
+	browserContext, err := browser.NewContext()
+	if err != nil {
+		return "", fmt.Errorf("could not create browser context: %w", err)
+	}
+	page, err := browserContext.NewPage()
+	if err != nil {
+		return "", fmt.Errorf("could not create page: %w", err)
+	}
+
+	if _, err := page.Goto(initResp.LoginURL); err != nil {
+		return "", fmt.Errorf("could not navigate to login URL: %w", err)
+	}
+
+	if mode != auth.ModeWebview {
+		if err := handler.Complete(ctx, page, initResp, creds); err != nil {
+			return "", fmt.Errorf("could not complete SSO flow in %s mode: %w", mode, err)
+		}
+	}
+
+	return waitForTokenCookie(ctx, browserContext, initResp.TokenCookieName)
+}
+
+func (b *playwrightBackend) launcher() (func(...playwright.BrowserTypeLaunchOptions) (playwright.Browser, error), error) {
+	switch b.browserType {
+	case "firefox":
+		return b.pw.Firefox.Launch, nil
+	case "chromium":
+		return b.pw.Chromium.Launch, nil
+	case "webkit":
+		return b.pw.WebKit.Launch, nil
+	default:
+		return nil, fmt.Errorf("unknown playwright browser type %q", b.browserType)
+	}
+}
+
+func (b *playwrightBackend) Close() error {
+	if b.browser != nil {
+		if err := b.browser.Close(); err != nil {
+			return err
+		}
+	}
+	if b.pw != nil {
+		return b.pw.Stop()
+	}
+	return nil
+}
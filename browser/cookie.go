@@ -0,0 +1,34 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mxschmitt/playwright-go"
+)
+
+// waitForTokenCookie polls the browser context's cookie jar until the
+// named token cookie appears or ctx is cancelled.
+func waitForTokenCookie(ctx context.Context, browserContext playwright.BrowserContext, cookieName string) (string, error) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		cookies, err := browserContext.Cookies()
+		if err != nil {
+			return "", fmt.Errorf("could not get cookies from browser context: %w", err)
+		}
+		for _, cookie := range cookies {
+			if cookie.Name == cookieName {
+				return cookie.Value, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
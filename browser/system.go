@@ -0,0 +1,160 @@
+package browser
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/tvandinther/go-openconnect-sso/config"
+	"github.com/tvandinther/go-openconnect-sso/internal/auth"
+)
+
+// systemBackend hands the IdP flow off to the user's default OS browser
+// and recovers the SSO token cookie through a short-lived loopback
+// callback server, for environments without Playwright browsers
+// installed.
+type systemBackend struct {
+	listener   net.Listener
+	server     *http.Server
+	tokenCh    chan string
+	cookieName string
+
+	// state is a random per-invocation value folded into the callback
+	// path so that only a request carrying this flow's callback URL, as
+	// the redirect and the userscript do, is accepted. The userscript
+	// page served at "/" embeds that URL, so a local process that
+	// fetches the page can learn the state.
+	state string
+}
+
+func newSystemBackend() (*systemBackend, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("could not start local callback server: %w", err)
+	}
+
+	state, err := newState()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate callback state: %w", err)
+	}
+
+	b := &systemBackend{listener: listener, tokenCh: make(chan string, 1), state: state}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback/", b.handleCallback)
+	mux.HandleFunc("/", b.handleIndex)
+	b.server = &http.Server{Handler: mux}
+
+	go b.server.Serve(listener)
+
+	return b, nil
+}
+
+// newState returns a random hex-encoded value suitable for use as a
+// per-invocation callback state token.
+func newState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// CallbackURL is registered with the server as part of the init request
+// so that an IdP configured to support it can redirect the user's
+// browser straight back to us with the token. It carries this
+// invocation's state value so handleCallback can reject requests that
+// don't originate from this flow.
+func (b *systemBackend) CallbackURL() string {
+	return fmt.Sprintf("http://%s/callback/%s", b.listener.Addr().String(), b.state)
+}
+
+func (b *systemBackend) Login(ctx context.Context, initResp config.InitializationResponse, mode auth.Mode, _ auth.Credentials) (string, error) {
+	if mode != auth.ModeWebview {
+		return "", fmt.Errorf("browser=system only supports mode=webview: headless and script modes require an embedded, automatable browser")
+	}
+
+	b.cookieName = initResp.TokenCookieName
+
+	if err := openInSystemBrowser(initResp.LoginURL); err != nil {
+		return "", fmt.Errorf("could not open system browser: %w", err)
+	}
+
+	select {
+	case token := <-b.tokenCh:
+		return token, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (b *systemBackend) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return b.server.Shutdown(ctx)
+}
+
+// handleCallback receives the token either from an IdP-initiated
+// redirect to our loopback URL, or from the userscript served at "/".
+// It is registered for the whole "/callback/" subtree and rejects any
+// request whose path doesn't carry this invocation's state, so a
+// concurrently-open page that has found the port by scanning localhost
+// can't race the real IdP redirect and feed us an attacker-chosen token.
+func (b *systemBackend) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimPrefix(r.URL.Path, "/callback/") != b.state {
+		http.Error(w, "invalid or missing callback state", http.StatusForbidden)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = r.FormValue("token")
+	}
+	if token == "" {
+		http.Error(w, "missing token parameter", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case b.tokenCh <- token:
+	default:
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, `<!DOCTYPE html><html><body><h1>Signed in</h1><p>You can close this tab and return to the terminal.</p></body></html>`)
+}
+
+// handleIndex serves a fallback userscript for IdPs that can't be
+// configured to redirect to our loopback URL: the user pastes it into
+// their browser's devtools console once the SSO flow completes.
+func (b *systemBackend) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html><html><body>
+<h1>go-openconnect-sso</h1>
+<p>If your IdP does not redirect back here automatically, once you reach
+the SSO success page, paste the following into your browser's devtools
+console:</p>
+<pre>fetch(%q + '?token=' + encodeURIComponent((document.cookie.split('; ').find(c => c.startsWith(%q + '=')) || '').split('=').slice(1).join('=')))</pre>
+</body></html>`, b.CallbackURL(), b.cookieName)
+}
+
+// openInSystemBrowser opens url in the OS default browser.
+func openInSystemBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,145 @@
+package browser
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tvandinther/go-openconnect-sso/config"
+	"github.com/tvandinther/go-openconnect-sso/internal/auth"
+)
+
+func TestSystemBackendHandleCallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    func(b *systemBackend) (*http.Request, error)
+		want       string
+		wantStatus int
+	}{
+		{
+			name: "token in query string",
+			request: func(b *systemBackend) (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, b.CallbackURL()+"?token=abc123", nil)
+			},
+			want:       "abc123",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "token in form body",
+			request: func(b *systemBackend) (*http.Request, error) {
+				req, err := http.NewRequest(http.MethodPost, b.CallbackURL(), strings.NewReader(url.Values{"token": {"def456"}}.Encode()))
+				if err != nil {
+					return nil, err
+				}
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req, nil
+			},
+			want:       "def456",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "missing token",
+			request: func(b *systemBackend) (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, b.CallbackURL(), nil)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "wrong state is rejected even with a valid token",
+			request: func(b *systemBackend) (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, "http://"+b.listener.Addr().String()+"/callback/not-the-real-state?token=abc123", nil)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := newSystemBackend()
+			if err != nil {
+				t.Fatalf("newSystemBackend: %v", err)
+			}
+			defer b.Close()
+
+			req, err := tt.request(b)
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			defer resp.Body.Close()
+			io.Copy(io.Discard, resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				select {
+				case got := <-b.tokenCh:
+					t.Errorf("token %q was accepted on tokenCh, want rejected", got)
+				default:
+				}
+				return
+			}
+
+			select {
+			case got := <-b.tokenCh:
+				if got != tt.want {
+					t.Errorf("token = %q, want %q", got, tt.want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for token on tokenCh")
+			}
+		})
+	}
+}
+
+func TestSystemBackendHandleIndexOnlySendsNamedCookie(t *testing.T) {
+	b, err := newSystemBackend()
+	if err != nil {
+		t.Fatalf("newSystemBackend: %v", err)
+	}
+	defer b.Close()
+	b.cookieName = "sso-token"
+
+	resp, err := http.Get("http://" + b.listener.Addr().String() + "/")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	if !strings.Contains(string(body), `startsWith("sso-token" + '=')`) {
+		t.Errorf("index page does not filter document.cookie down to the named cookie, got: %s", body)
+	}
+	if strings.Contains(string(body), "encodeURIComponent(document.cookie))") {
+		t.Errorf("index page still sends the entire document.cookie blob, got: %s", body)
+	}
+}
+
+func TestSystemBackendLoginRejectsNonWebviewModes(t *testing.T) {
+	b, err := newSystemBackend()
+	if err != nil {
+		t.Fatalf("newSystemBackend: %v", err)
+	}
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = b.Login(ctx, config.InitializationResponse{}, auth.ModeHeadless, auth.Credentials{})
+	if err == nil {
+		t.Fatal("Login with mode=headless: got nil error, want error")
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/tvandinther/go-openconnect-sso/browser"
+	"github.com/tvandinther/go-openconnect-sso/internal/auth"
+	"github.com/tvandinther/go-openconnect-sso/pkg/sso"
+	"github.com/tvandinther/go-openconnect-sso/session"
+	"github.com/tvandinther/go-openconnect-sso/transport"
+)
+
+// connectOptions holds the flags shared by the one-shot `connect` command
+// and the initial authentication performed by `daemon`.
+type connectOptions struct {
+	server     string
+	ocFile     string
+	logFormat  string
+	logLevel   string
+	mode       string
+	browser    string
+	username   string
+	password   string
+	timeout    time.Duration
+	noCache    bool
+	cacheFile  string
+	passphrase string
+	sessionTTL time.Duration
+
+	proxy       string
+	caFile      string
+	insecure    bool
+	serverCert  string
+	httpTimeout time.Duration
+	maxRetries  int
+}
+
+// addConnectFlags registers the flags common to `connect` and `daemon` on
+// fs and returns the struct they populate.
+func addConnectFlags(fs *flag.FlagSet) *connectOptions {
+	opts := &connectOptions{}
+	fs.StringVar(&opts.server, "server", "", "the OpenConnect VPN server address")
+	fs.StringVar(&opts.ocFile, "config", "", "where the OpenConnect config file will be saved")
+	fs.StringVar(&opts.logFormat, "log-format", "text", "log format (json or text)")
+	fs.StringVar(&opts.logLevel, "log-level", "info", "log level [WARNING: 'debug' level will print openconnect login cookie to the console] (info, warn, error, debug, none)")
+	fs.StringVar(&opts.mode, "mode", "webview", "SSO completion mode (webview, headless, script)")
+	fs.StringVar(&opts.browser, "browser", "firefox", "browser backend to drive the SSO flow with (firefox, chromium, webkit, system)")
+	fs.StringVar(&opts.username, "username", os.Getenv("OPENCONNECT_SSO_USERNAME"), "username for --mode=headless (or OPENCONNECT_SSO_USERNAME)")
+	fs.StringVar(&opts.password, "password", os.Getenv("OPENCONNECT_SSO_PASSWORD"), "password for --mode=headless (or OPENCONNECT_SSO_PASSWORD)")
+	fs.DurationVar(&opts.timeout, "timeout", 5*time.Minute, "maximum time to wait for the SSO flow to complete")
+	fs.BoolVar(&opts.noCache, "no-cache", false, "skip the session cache and always run the full SSO flow")
+	fs.StringVar(&opts.cacheFile, "cache-file", "", "path to the encrypted session cache file (default: $XDG_CONFIG_HOME/go-openconnect-sso/sessions.enc)")
+	fs.StringVar(&opts.passphrase, "passphrase", os.Getenv("OPENCONNECT_SSO_PASSPHRASE"), "passphrase used to derive the session cache encryption key (or OPENCONNECT_SSO_PASSPHRASE); there is no OS keyring integration yet, so this is the only key source")
+	fs.DurationVar(&opts.sessionTTL, "session-ttl", time.Hour, "how long a cached session is considered valid before it must be re-authenticated")
+	fs.StringVar(&opts.proxy, "proxy", "", "HTTP(S) proxy to use for requests to the ASA front-end (default: HTTPS_PROXY/HTTP_PROXY/NO_PROXY env vars)")
+	fs.StringVar(&opts.caFile, "ca-file", "", "PEM file of additional CA certificates to trust when talking to the ASA front-end")
+	fs.BoolVar(&opts.insecure, "insecure", false, "disable TLS certificate verification for requests to the ASA front-end")
+	fs.StringVar(&opts.serverCert, "server-cert", "", "expected server fingerprint (sha256:...), checked against the one the server reports before it is written to the oc-config file")
+	fs.DurationVar(&opts.httpTimeout, "http-timeout", 30*time.Second, "timeout for a request to the ASA front-end, including any retries")
+	fs.IntVar(&opts.maxRetries, "max-retries", 2, "number of retries for requests to the ASA front-end that fail with a 5xx response or a transient network error")
+	return opts
+}
+
+// authResult is the outcome of authenticate: everything a caller needs
+// either to write an oc-config once (`connect`) or to keep re-minting one
+// (`daemon`).
+type authResult struct {
+	final        sso.FinalResponse
+	targetServer string
+	tokenCookie  string
+	opaqueValue  string
+	fromCache    bool
+}
+
+// newHTTPClient builds the *http.Client used for requests to the ASA
+// front-end, configured per the --proxy/--ca-file/--insecure/
+// --http-timeout/--max-retries flags.
+func (opts *connectOptions) newHTTPClient() (*http.Client, error) {
+	return transport.NewClient(transport.Options{
+		ProxyURL:   opts.proxy,
+		CAFile:     opts.caFile,
+		Insecure:   opts.insecure,
+		Timeout:    opts.httpTimeout,
+		MaxRetries: opts.maxRetries,
+	})
+}
+
+// authenticate resolves a session for opts.server, reusing a cached one
+// when available and falling back to the full browser-driven SSO flow
+// otherwise. The returned store, if non-nil, should be used to persist a
+// freshly obtained session.
+func authenticate(ctx context.Context, opts *connectOptions) (authResult, *session.Store, error) {
+	client, err := opts.newHTTPClient()
+	if err != nil {
+		return authResult{}, nil, fmt.Errorf("could not build HTTP client: %w", err)
+	}
+
+	store := openSessionStore(opts.noCache, opts.cacheFile, opts.passphrase)
+	if store != nil {
+		if finalResp, targetServer, token, opaque, ok := tryReuseSession(ctx, client, store, opts.server); ok {
+			if err := transport.VerifyFingerprint(opts.serverCert, finalResp.Fingerprint); err != nil {
+				return authResult{}, nil, err
+			}
+			return authResult{
+				final:        finalResp,
+				targetServer: targetServer,
+				tokenCookie:  token,
+				opaqueValue:  opaque,
+				fromCache:    true,
+			}, store, nil
+		}
+	}
+
+	authMode, err := auth.ParseMode(opts.mode)
+	if err != nil {
+		return authResult{}, nil, fmt.Errorf("invalid mode: %w", err)
+	}
+
+	backend, err := browser.New(opts.browser)
+	if err != nil {
+		return authResult{}, nil, fmt.Errorf("invalid browser: %w", err)
+	}
+	defer backend.Close()
+
+	ssoClient := sso.NewClient(client)
+	ssoClient.SetCallbackURL(backend.CallbackURL())
+
+	initResp, err := ssoClient.Initialize(ctx, opts.server)
+	if err != nil {
+		return authResult{}, nil, err
+	}
+
+	log.Info("waiting to detect successful authentication token cookie", "mode", authMode, "browser", opts.browser)
+	creds := auth.Credentials{Username: opts.username, Password: opts.password}
+	source := backendCookieSource{backend: backend, mode: authMode, creds: creds}
+	tokenCookie, err := ssoClient.WaitForToken(ctx, source)
+	if err != nil {
+		return authResult{}, nil, err
+	}
+	log.Info("received successful authentication token cookie from browser")
+
+	finalResp, err := ssoClient.Finalize(ctx, tokenCookie, initResp.Opaque.Value)
+	if err != nil {
+		return authResult{}, nil, err
+	}
+	if err := transport.VerifyFingerprint(opts.serverCert, finalResp.Fingerprint); err != nil {
+		return authResult{}, nil, err
+	}
+	log.Info("received openconnect server fingerprint and connection cookie successfully")
+
+	return authResult{
+		final:        *finalResp,
+		targetServer: ssoClient.TargetServer(),
+		tokenCookie:  tokenCookie,
+		opaqueValue:  initResp.Opaque.Value,
+	}, store, nil
+}
+
+// Connect runs the one-shot flow: authenticate once and write the
+// resulting oc-config file. It returns the process exit code.
+func Connect(args []string) int {
+	fs := flag.NewFlagSet("connect", flag.ExitOnError)
+	opts := addConnectFlags(fs)
+	fs.Parse(args)
+
+	log = setupLogger(opts.logFormat, opts.logLevel)
+	log.Info("Logger initialized")
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	result, store, err := authenticate(ctx, opts)
+	if err != nil {
+		log.Error("authentication failed", "err", err)
+		return 1
+	}
+
+	if store != nil && !result.fromCache {
+		sess := session.Session{
+			Server:    result.targetServer,
+			Token:     result.tokenCookie,
+			Opaque:    result.opaqueValue,
+			ExpiresAt: time.Now().Add(opts.sessionTTL),
+		}
+		if err := store.Save(sess); err != nil {
+			log.Warn("could not persist session to cache", "err", err)
+		}
+	}
+
+	if err := writeOCConfig(result.final.Cookie, result.final.Fingerprint, result.targetServer, opts.ocFile); err != nil {
+		log.Error("could not write oc-config", "err", err)
+		return 1
+	}
+	return 0
+}
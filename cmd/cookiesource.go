@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/tvandinther/go-openconnect-sso/browser"
+	"github.com/tvandinther/go-openconnect-sso/internal/auth"
+	"github.com/tvandinther/go-openconnect-sso/pkg/sso"
+)
+
+// backendCookieSource adapts a browser.Backend, bound to a mode and a
+// set of credentials, into an sso.CookieSource.
+type backendCookieSource struct {
+	backend browser.Backend
+	mode    auth.Mode
+	creds   auth.Credentials
+}
+
+func (s backendCookieSource) Obtain(ctx context.Context, init *sso.InitResponse) (string, error) {
+	return s.backend.Login(ctx, *init, s.mode, s.creds)
+}
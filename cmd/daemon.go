@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/tvandinther/go-openconnect-sso/pkg/sso"
+	"github.com/tvandinther/go-openconnect-sso/session"
+	"github.com/tvandinther/go-openconnect-sso/transport"
+)
+
+// daemonOptions extends connectOptions with the refresh-loop settings
+// specific to `daemon`.
+type daemonOptions struct {
+	*connectOptions
+	interval       time.Duration
+	reconnectCmd   string
+	openconnectPID int
+}
+
+func addDaemonFlags(fs *flag.FlagSet) *daemonOptions {
+	opts := &daemonOptions{connectOptions: addConnectFlags(fs)}
+	fs.DurationVar(&opts.interval, "interval", 45*time.Minute, "how often to re-run finalization and mint a fresh connection cookie")
+	fs.StringVar(&opts.reconnectCmd, "reconnect-cmd", "", "shell command to run after each refresh, e.g. to nudge openconnect into picking up the new cookie")
+	fs.IntVar(&opts.openconnectPID, "openconnect-pid", 0, "PID of a supervised openconnect process to send SIGUSR1 after each refresh")
+	return opts
+}
+
+// Daemon runs the refresh-token style supervisor loop: it authenticates
+// once, writes the oc-config, and then keeps re-running finalization on
+// a timer (or on SIGHUP) to mint a fresh connection cookie before the
+// old one expires, until it receives SIGINT/SIGTERM. It returns the
+// process exit code.
+func Daemon(args []string) int {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	opts := addDaemonFlags(fs)
+	fs.Parse(args)
+
+	log = setupLogger(opts.logFormat, opts.logLevel)
+	log.Info("Logger initialized")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	authCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+	result, store, err := authenticate(authCtx, opts.connectOptions)
+	cancel()
+	if err != nil {
+		log.Error("initial authentication failed", "err", err)
+		return 1
+	}
+
+	if store != nil && !result.fromCache {
+		sess := session.Session{
+			Server:    result.targetServer,
+			Token:     result.tokenCookie,
+			Opaque:    result.opaqueValue,
+			ExpiresAt: time.Now().Add(opts.sessionTTL),
+		}
+		if err := store.Save(sess); err != nil {
+			log.Warn("could not persist session to cache", "err", err)
+		}
+	}
+
+	if err := writeOCConfig(result.final.Cookie, result.final.Fingerprint, result.targetServer, opts.ocFile); err != nil {
+		log.Error("could not write oc-config", "err", err)
+		return 1
+	}
+
+	client, err := opts.newHTTPClient()
+	if err != nil {
+		log.Error("could not build HTTP client for refresh loop", "err", err)
+		return 1
+	}
+	ssoClient := sso.NewClient(client)
+	ssoClient.Resume(result.targetServer)
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	log.Info("entering refresh loop", "interval", opts.interval, "server", result.targetServer)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("received shutdown signal, exiting")
+			return 0
+		case <-sighup:
+			log.Info("received SIGHUP, refreshing connection cookie early")
+		case <-ticker.C:
+			log.Info("refresh interval elapsed, refreshing connection cookie")
+		}
+
+		refreshCtx, refreshCancel := context.WithTimeout(ctx, opts.timeout)
+		finalResp, err := ssoClient.Finalize(refreshCtx, result.tokenCookie, result.opaqueValue)
+		refreshCancel()
+		if err != nil {
+			log.Error("failed to refresh connection cookie, will retry on the next tick", "err", err)
+			continue
+		}
+		if err := transport.VerifyFingerprint(opts.serverCert, finalResp.Fingerprint); err != nil {
+			log.Error("refreshed connection cookie failed fingerprint verification, will retry on the next tick", "err", err)
+			continue
+		}
+
+		if err := writeOCConfig(finalResp.Cookie, finalResp.Fingerprint, result.targetServer, opts.ocFile); err != nil {
+			log.Error("could not rewrite oc-config after refresh", "err", err)
+			continue
+		}
+
+		notifySupervisor(opts.openconnectPID, opts.reconnectCmd)
+	}
+}
+
+// notifySupervisor tells a running openconnect process (or a custom
+// reconnect command) that a fresh cookie is available.
+func notifySupervisor(pid int, reconnectCmd string) {
+	if pid > 0 {
+		proc, err := os.FindProcess(pid)
+		if err != nil {
+			log.Warn("could not find supervised openconnect process", "pid", pid, "err", err)
+		} else if err := proc.Signal(syscall.SIGUSR1); err != nil {
+			log.Warn("could not signal supervised openconnect process", "pid", pid, "err", err)
+		}
+	}
+
+	if reconnectCmd != "" {
+		cmd := exec.Command("sh", "-c", reconnectCmd)
+		if err := cmd.Run(); err != nil {
+			log.Warn("reconnect command failed", "cmd", reconnectCmd, "err", err)
+		}
+	}
+}
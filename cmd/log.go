@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+)
+
+var log *slog.Logger
+
+func setupLogger(format, level string) *slog.Logger {
+	var slogLevel slog.Level
+	switch level {
+	case "debug":
+		slogLevel = slog.LevelDebug
+	case "info":
+		slogLevel = slog.LevelInfo
+	case "warn":
+		slogLevel = slog.LevelWarn
+	case "error":
+		slogLevel = slog.LevelError
+	case "none":
+		// Custom "none" level: set to a high level to suppress logs
+		slogLevel = slog.Level(100)
+	default:
+		slogLevel = slog.LevelInfo
+	}
+
+	handlerOpts := &slog.HandlerOptions{
+		Level: slogLevel,
+	}
+
+	var handler slog.Handler
+	switch format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, handlerOpts)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, handlerOpts)
+	default:
+		handler = slog.NewTextHandler(os.Stderr, handlerOpts)
+	}
+
+	// Tag every record with a constant ts=utc attribute; source
+	// locations are not added
+	handler = handler.WithAttrs([]slog.Attr{
+		slog.String("ts", "utc"),
+	})
+
+	logger := slog.New(handler)
+
+	return logger
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"flag"
+
+	"github.com/tvandinther/go-openconnect-sso/session"
+)
+
+// Logout implements the `logout`/`purge` subcommand, removing any cached
+// session(s) from disk. It returns the process exit code.
+func Logout(args []string) int {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	cacheFile := fs.String("cache-file", "", "path to the encrypted session cache file (default: $XDG_CONFIG_HOME/go-openconnect-sso/sessions.enc)")
+	logLevel := fs.String("log-level", "info", "log level (info, warn, error, debug, none)")
+	fs.Parse(args)
+
+	log = setupLogger("text", *logLevel)
+
+	path := *cacheFile
+	if path == "" {
+		var err error
+		path, err = session.DefaultCacheFile()
+		if err != nil {
+			log.Error("could not determine default session cache location", "err", err)
+			return 1
+		}
+	}
+
+	store, err := session.NewStore(path, "unused")
+	if err != nil {
+		log.Error("could not open session cache", "err", err)
+		return 1
+	}
+	if err := store.Purge(); err != nil {
+		log.Error("could not purge session cache", "err", err)
+		return 1
+	}
+	log.Info("purged session cache", "file", path)
+	return 0
+}
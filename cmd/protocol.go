@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeOCConfig atomically (re)writes the OpenConnect config file: the
+// content is written to a temp file in the same directory and then
+// renamed over ocFile, so a concurrently-running `openconnect` never
+// observes a partially written file.
+func writeOCConfig(cookie, fingerprint, server, ocFile string) error {
+	content := fmt.Sprintf("cookie=%s\nservercert=%s\n# host=%s\n", cookie, fingerprint, server)
+
+	tmp, err := os.CreateTemp(filepath.Dir(ocFile), filepath.Base(ocFile)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %q: %w", ocFile, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write([]byte(content)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write authentication details to temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to set permissions on temp file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), ocFile); err != nil {
+		return fmt.Errorf("failed to move temp file into place at %q: %w", ocFile, err)
+	}
+
+	log.Info("successfully written authentication details to file", "file", ocFile)
+	return nil
+}
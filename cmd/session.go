@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/tvandinther/go-openconnect-sso/pkg/sso"
+	"github.com/tvandinther/go-openconnect-sso/session"
+)
+
+// openSessionStore opens the session cache unless disabled, logging and
+// continuing without a cache if it cannot be opened (e.g. no passphrase
+// configured).
+func openSessionStore(noCache bool, cacheFile, passphrase string) *session.Store {
+	if noCache {
+		return nil
+	}
+	if cacheFile == "" {
+		var err error
+		cacheFile, err = session.DefaultCacheFile()
+		if err != nil {
+			log.Warn("could not determine default session cache location, continuing without a cache", "err", err)
+			return nil
+		}
+	}
+	store, err := session.NewStore(cacheFile, passphrase)
+	if err != nil {
+		log.Warn("session cache disabled", "err", err)
+		return nil
+	}
+	return store
+}
+
+// tryReuseSession attempts to finalize using a cached, non-expired
+// session for server, skipping the browser flow entirely. It reports ok
+// == false when the caller should fall back to the interactive flow,
+// invalidating any cached entry that the server rejects. On success it
+// also returns the cached token/opaque pair so the caller can keep
+// refreshing the session (e.g. in `daemon`'s loop) instead of treating
+// it as a dead end.
+func tryReuseSession(ctx context.Context, client *http.Client, store *session.Store, server string) (finalResp sso.FinalResponse, targetServer, token, opaque string, ok bool) {
+	sess, err := store.Load(server)
+	if err != nil {
+		return sso.FinalResponse{}, "", "", "", false
+	}
+	if sess.Expired() {
+		log.Info("cached session has expired, falling back to interactive flow", "server", server)
+		return sso.FinalResponse{}, "", "", "", false
+	}
+
+	log.Info("reusing cached session, skipping browser flow", "server", server)
+	ssoClient := sso.NewClient(client)
+	ssoClient.Resume(sess.Server)
+	resp, err := ssoClient.Finalize(ctx, sess.Token, sess.Opaque)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			log.Warn("could not reach the server to reuse the cached session, falling back to interactive flow", "err", err)
+			return sso.FinalResponse{}, "", "", "", false
+		}
+		log.Warn("cached session was rejected by the server, falling back to interactive flow", "err", err)
+		if err := store.Invalidate(sess.Server); err != nil {
+			log.Warn("could not invalidate rejected session", "err", err)
+		}
+		return sso.FinalResponse{}, "", "", "", false
+	}
+	return *resp, sess.Server, sess.Token, sess.Opaque, true
+}
@@ -0,0 +1,57 @@
+// Package config contains the XML types used to (un)marshal the
+// config-auth documents exchanged with the ASA/ASAv SSO endpoint during
+// the AnyConnect-compatible authentication handshake.
+package config
+
+import "encoding/xml"
+
+// Opaque is echoed back to the server on the finalization request exactly
+// as it was received during initialization.
+type Opaque struct {
+	IsFor string `xml:"is-for,attr"`
+	Value string `xml:",chardata"`
+}
+
+// FormField describes a single input the IdP's login form expects, as
+// surfaced by the server in non-webview auth methods.
+type FormField struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Label string `xml:",chardata"`
+}
+
+// Form is the login form the server expects to be submitted when the
+// auth-method does not support a rendered SSO webview.
+type Form struct {
+	Method string      `xml:"method,attr"`
+	Action string      `xml:"action,attr"`
+	Fields []FormField `xml:"input"`
+}
+
+// InitializationResponse is the config-auth document returned for the
+// stage-1 "init" request, carrying everything needed to drive the IdP
+// flow to completion.
+type InitializationResponse struct {
+	XMLName         xml.Name `xml:"config-auth"`
+	LoginURL        string   `xml:"auth>sso-v2-login"`
+	LoginFinalURL   string   `xml:"auth>sso-v2-login-final"`
+	TokenCookieName string   `xml:"auth>sso-v2-token-cookie-name"`
+	Opaque          Opaque   `xml:"opaque"`
+	Message         string   `xml:"auth>message"`
+	// Form is populated when the server falls back to a plain login form
+	// instead of (or in addition to) the SSO webview flow.
+	Form *Form `xml:"auth>form"`
+	// AuthScript is the JavaScript blob the Cisco Secure Client executes
+	// against the rendered login page to complete authentication without
+	// a visible browser. Empty when the server does not offer it.
+	AuthScript string `xml:"auth>auth-script"`
+}
+
+// FinalizationResponse is the config-auth document returned for the
+// stage-2 "auth-reply" request, carrying the connection cookie and server
+// fingerprint OpenConnect needs to establish the tunnel.
+type FinalizationResponse struct {
+	XMLName     xml.Name `xml:"config-auth"`
+	Cookie      string   `xml:"auth>session-token"`
+	Fingerprint string   `xml:"config>vpn-base-config>server-cert-hash"`
+}
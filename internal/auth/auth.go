@@ -0,0 +1,151 @@
+// Package auth provides pluggable handlers for driving the IdP login page
+// to completion under the different SSO modes the tool supports: a
+// human-operated webview, a fully headless credential auto-fill, and a
+// scripted auth-completion mode matching what the Cisco Secure Client
+// does when the server offers an auth-script instead of a form.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mxschmitt/playwright-go"
+	"github.com/tvandinther/go-openconnect-sso/config"
+)
+
+// Mode selects how the IdP login page is driven to completion.
+type Mode string
+
+const (
+	// ModeWebview preserves the original behaviour: a visible browser
+	// window is left for a human to complete the IdP flow in.
+	ModeWebview Mode = "webview"
+	// ModeHeadless auto-fills the server-provided login form with
+	// credentials supplied out of band and submits it without a visible
+	// browser.
+	ModeHeadless Mode = "headless"
+	// ModeScript executes the auth-completion JavaScript blob returned by
+	// the server against the rendered page, matching the Cisco Secure
+	// Client's behaviour for auth-methods that offer one.
+	ModeScript Mode = "script"
+)
+
+// ParseMode validates a --mode flag value.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case ModeWebview, ModeHeadless, ModeScript:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("unknown mode %q: must be one of webview, headless, script", s)
+	}
+}
+
+// Credentials are the username/password pair used by ModeHeadless to fill
+// in the IdP's login form.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Handler drives a rendered IdP login page to completion for a given
+// mode. Implementations return once they have done everything they can;
+// the caller is still responsible for waiting on the token cookie.
+type Handler interface {
+	Complete(ctx context.Context, page playwright.Page, initResp config.InitializationResponse, creds Credentials) error
+}
+
+// NewHandler returns the Handler for the given mode.
+func NewHandler(mode Mode) (Handler, error) {
+	switch mode {
+	case ModeWebview:
+		return webviewHandler{}, nil
+	case ModeHeadless:
+		return headlessHandler{}, nil
+	case ModeScript:
+		return scriptHandler{}, nil
+	default:
+		return nil, fmt.Errorf("unknown mode %q", mode)
+	}
+}
+
+// webviewHandler leaves the page for a human to complete; there is
+// nothing to do here beyond honouring cancellation.
+type webviewHandler struct{}
+
+func (webviewHandler) Complete(ctx context.Context, _ playwright.Page, _ config.InitializationResponse, _ Credentials) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// headlessHandler auto-fills the server-provided login form and submits
+// it using the supplied credentials.
+type headlessHandler struct{}
+
+func (headlessHandler) Complete(ctx context.Context, page playwright.Page, initResp config.InitializationResponse, creds Credentials) error {
+	if initResp.Form == nil {
+		return fmt.Errorf("server did not offer a login form for headless mode")
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return fmt.Errorf("headless mode requires both a username and a password")
+	}
+
+	for _, field := range initResp.Form.Fields {
+		value := fieldValue(field, creds)
+		if value == "" {
+			continue
+		}
+		selector := fmt.Sprintf(`[name=%q]`, field.Name)
+		if _, err := page.WaitForSelector(selector, playwright.PageWaitForSelectorOptions{
+			Timeout: playwright.Int(remainingMillis(ctx, 30*time.Second)),
+		}); err != nil {
+			return fmt.Errorf("waiting for form field %q: %w", field.Name, err)
+		}
+		if err := page.Fill(selector, value); err != nil {
+			return fmt.Errorf("filling form field %q: %w", field.Name, err)
+		}
+	}
+
+	if err := page.Keyboard().Press("Enter"); err != nil {
+		return fmt.Errorf("submitting login form: %w", err)
+	}
+	return nil
+}
+
+func fieldValue(field config.FormField, creds Credentials) string {
+	switch field.Type {
+	case "text", "email":
+		return creds.Username
+	case "password":
+		return creds.Password
+	default:
+		return ""
+	}
+}
+
+// scriptHandler executes the server-supplied auth-completion JavaScript
+// against the rendered page.
+type scriptHandler struct{}
+
+func (scriptHandler) Complete(_ context.Context, page playwright.Page, initResp config.InitializationResponse, _ Credentials) error {
+	if initResp.AuthScript == "" {
+		return fmt.Errorf("server did not offer an auth-script for script mode")
+	}
+	if _, err := page.Evaluate(initResp.AuthScript); err != nil {
+		return fmt.Errorf("executing auth-script: %w", err)
+	}
+	return nil
+}
+
+// remainingMillis returns the time left until ctx's deadline, or def if
+// ctx has none.
+func remainingMillis(ctx context.Context, def time.Duration) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return int(def.Milliseconds())
+	}
+	if remaining := time.Until(deadline); remaining > 0 {
+		return int(remaining.Milliseconds())
+	}
+	return 0
+}
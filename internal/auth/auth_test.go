@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mxschmitt/playwright-go"
+	"github.com/tvandinther/go-openconnect-sso/config"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Mode
+		wantErr bool
+	}{
+		{"webview", ModeWebview, false},
+		{"headless", ModeHeadless, false},
+		{"script", ModeScript, false},
+		{"bogus", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMode(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMode(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMode(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	creds := Credentials{Username: "alice", Password: "hunter2"}
+	tests := []struct {
+		fieldType string
+		want      string
+	}{
+		{"text", "alice"},
+		{"email", "alice"},
+		{"password", "hunter2"},
+		{"hidden", ""},
+		{"checkbox", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fieldType, func(t *testing.T) {
+			got := fieldValue(config.FormField{Type: tt.fieldType}, creds)
+			if got != tt.want {
+				t.Errorf("fieldValue(type=%q) = %q, want %q", tt.fieldType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemainingMillis(t *testing.T) {
+	t.Run("no deadline returns the default", func(t *testing.T) {
+		got := remainingMillis(context.Background(), 30*time.Second)
+		if got != 30000 {
+			t.Errorf("remainingMillis = %d, want 30000", got)
+		}
+	})
+
+	t.Run("future deadline returns roughly the time left", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		got := remainingMillis(ctx, 30*time.Second)
+		if got <= 0 || got > 60000 {
+			t.Errorf("remainingMillis = %d, want in (0, 60000]", got)
+		}
+	})
+
+	t.Run("past deadline returns zero", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+		<-ctx.Done()
+		got := remainingMillis(ctx, 30*time.Second)
+		if got != 0 {
+			t.Errorf("remainingMillis = %d, want 0", got)
+		}
+	})
+}
+
+// fakePage is a minimal playwright.Page test fake recording the handler
+// interactions that headlessHandler/scriptHandler care about; embedding
+// the nil interface lets it stand in for Page without implementing the
+// hundreds of unrelated methods.
+type fakePage struct {
+	playwright.Page
+
+	filled      map[string]string
+	waitErr     error
+	pressed     bool
+	pressErr    error
+	evaluated   string
+	evaluateErr error
+}
+
+func (p *fakePage) WaitForSelector(selector string, options ...playwright.PageWaitForSelectorOptions) (playwright.ElementHandle, error) {
+	return nil, p.waitErr
+}
+
+func (p *fakePage) Fill(selector, value string, options ...playwright.FrameFillOptions) error {
+	if p.filled == nil {
+		p.filled = map[string]string{}
+	}
+	p.filled[selector] = value
+	return nil
+}
+
+func (p *fakePage) Keyboard() playwright.Keyboard {
+	return &fakeKeyboard{page: p}
+}
+
+func (p *fakePage) Evaluate(expression string, options ...interface{}) (interface{}, error) {
+	p.evaluated = expression
+	return nil, p.evaluateErr
+}
+
+type fakeKeyboard struct {
+	playwright.Keyboard
+	page *fakePage
+}
+
+func (k *fakeKeyboard) Press(key string, options ...playwright.KeyboardPressOptions) error {
+	k.page.pressed = true
+	return k.page.pressErr
+}
+
+func TestWebviewHandlerCompleteWaitsForCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := webviewHandler{}.Complete(ctx, &fakePage{}, config.InitializationResponse{}, Credentials{})
+	if err == nil {
+		t.Fatal("Complete on a cancelled context returned nil, want an error")
+	}
+}
+
+func TestHeadlessHandlerComplete(t *testing.T) {
+	initResp := config.InitializationResponse{
+		Form: &config.Form{
+			Fields: []config.FormField{
+				{Name: "username", Type: "text"},
+				{Name: "password", Type: "password"},
+			},
+		},
+	}
+	creds := Credentials{Username: "alice", Password: "hunter2"}
+
+	t.Run("fills and submits the form", func(t *testing.T) {
+		page := &fakePage{}
+		if err := (headlessHandler{}).Complete(context.Background(), page, initResp, creds); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		if page.filled["[name=\"username\"]"] != "alice" {
+			t.Errorf("username field = %q, want %q", page.filled["[name=\"username\"]"], "alice")
+		}
+		if page.filled["[name=\"password\"]"] != "hunter2" {
+			t.Errorf("password field = %q, want %q", page.filled["[name=\"password\"]"], "hunter2")
+		}
+		if !page.pressed {
+			t.Error("Complete did not submit the form with Enter")
+		}
+	})
+
+	t.Run("requires a form", func(t *testing.T) {
+		err := (headlessHandler{}).Complete(context.Background(), &fakePage{}, config.InitializationResponse{}, creds)
+		if err == nil {
+			t.Fatal("Complete with no form returned nil, want an error")
+		}
+	})
+
+	t.Run("requires both username and password", func(t *testing.T) {
+		err := (headlessHandler{}).Complete(context.Background(), &fakePage{}, initResp, Credentials{Username: "alice"})
+		if err == nil {
+			t.Fatal("Complete with a missing password returned nil, want an error")
+		}
+	})
+
+	t.Run("propagates a field wait error", func(t *testing.T) {
+		page := &fakePage{waitErr: context.DeadlineExceeded}
+		err := (headlessHandler{}).Complete(context.Background(), page, initResp, creds)
+		if err == nil {
+			t.Fatal("Complete returned nil despite WaitForSelector failing, want an error")
+		}
+	})
+}
+
+func TestScriptHandlerComplete(t *testing.T) {
+	t.Run("executes the auth-script", func(t *testing.T) {
+		page := &fakePage{}
+		initResp := config.InitializationResponse{AuthScript: "window.submitSSO()"}
+		if err := (scriptHandler{}).Complete(context.Background(), page, initResp, Credentials{}); err != nil {
+			t.Fatalf("Complete: %v", err)
+		}
+		if page.evaluated != "window.submitSSO()" {
+			t.Errorf("evaluated = %q, want %q", page.evaluated, "window.submitSSO()")
+		}
+	})
+
+	t.Run("requires an auth-script", func(t *testing.T) {
+		err := (scriptHandler{}).Complete(context.Background(), &fakePage{}, config.InitializationResponse{}, Credentials{})
+		if err == nil {
+			t.Fatal("Complete with no auth-script returned nil, want an error")
+		}
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		mode    Mode
+		want    Handler
+		wantErr bool
+	}{
+		{ModeWebview, webviewHandler{}, false},
+		{ModeHeadless, headlessHandler{}, false},
+		{ModeScript, scriptHandler{}, false},
+		{Mode("bogus"), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			got, err := NewHandler(tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewHandler(%q) error = %v, wantErr %v", tt.mode, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("NewHandler(%q) = %#v, want %#v", tt.mode, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Package sso implements the Cisco AnyConnect-compatible SSO handshake
+// against an ASA/ASAv front-end as a reusable, embeddable library: it
+// performs the stage-1 "init" and stage-2 "auth-reply" requests but
+// leaves obtaining the SSO token cookie itself to the caller, so it can
+// be driven by a browser, a headless script runner, or a test fake.
+package sso
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tvandinther/go-openconnect-sso/config"
+)
+
+// InitResponse is the config-auth document returned for the stage-1
+// "init" request.
+type InitResponse = config.InitializationResponse
+
+// FinalResponse is the config-auth document returned for the stage-2
+// "auth-reply" request.
+type FinalResponse = config.FinalizationResponse
+
+// CookieSource drives the IdP-specific part of the SSO flow to
+// completion and returns the token cookie the ASA expects back in
+// Finalize. Implementations include a Playwright-driven browser, a
+// headless auth script runner, and test fakes.
+type CookieSource interface {
+	Obtain(ctx context.Context, init *InitResponse) (string, error)
+}
+
+// Client drives the two-stage ASA SSO handshake over an injected
+// *http.Client. A Client is not safe for concurrent use: Initialize (or
+// Resume), WaitForToken, and Finalize are meant to be called in sequence
+// for a single authentication attempt.
+type Client struct {
+	http *http.Client
+
+	callbackURL  string
+	targetServer string
+	initResp     InitResponse
+}
+
+// NewClient returns a Client that issues requests through httpClient.
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{http: httpClient}
+}
+
+// SetCallbackURL registers a loopback URL with the server as part of
+// Initialize, so an IdP that supports it can redirect the user's
+// browser straight back to the caller with the token instead of relying
+// on cookie polling. It must be called before Initialize.
+func (c *Client) SetCallbackURL(url string) {
+	c.callbackURL = url
+}
+
+// TargetServer returns the VPN server URL resolved by the most recent
+// Initialize or Resume call, e.g. for callers that need to record which
+// server a session belongs to.
+func (c *Client) TargetServer() string {
+	return c.targetServer
+}
+
+// Resume prepares the Client to Finalize a previously obtained session
+// for server without re-running Initialize, for callers reusing a
+// cached token and opaque value.
+func (c *Client) Resume(server string) {
+	c.targetServer = server
+}
+
+// Initialize performs the stage-1 "init" request against serverURL and
+// returns the parsed response. The Client also keeps it, and
+// WaitForToken hands it to the CookieSource to drive the rest of the
+// flow.
+func (c *Client) Initialize(ctx context.Context, serverURL string) (*InitResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", serverURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sso: failed to create http request: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sso: failed to get url %q: %w", serverURL, err)
+	}
+	resp.Body.Close()
+	targetServer := resp.Request.URL.String()
+
+	var callbackElement string
+	if c.callbackURL != "" {
+		callbackElement = fmt.Sprintf("\n      <callback-url>%s</callback-url>", c.callbackURL)
+	}
+
+	xmlPayload := fmt.Sprintf(`
+    <config-auth client="vpn" type="init" aggregate-auth-version="2">
+      <version who="vpn">4.7.00136</version>
+      <device-id>linux-64</device-id>
+      <group-select></group-select>
+			<group-access>%s</group-access>
+      <capabilities>
+        <auth-method>single-sign-on-v2</auth-method>
+      </capabilities>%s
+    </config-auth>
+	`, targetServer, callbackElement)
+
+	body, err := c.post(ctx, xmlPayload, targetServer)
+	if err != nil {
+		return nil, fmt.Errorf("sso: initialization request failed: %w", err)
+	}
+
+	var result InitResponse
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("sso: failed to unmarshal initialization response: %w", err)
+	}
+
+	c.targetServer = targetServer
+	c.initResp = result
+	return &result, nil
+}
+
+// WaitForToken asks source to drive the IdP flow described by the most
+// recent Initialize call to completion and returns the SSO token
+// cookie. Initialize must be called first.
+func (c *Client) WaitForToken(ctx context.Context, source CookieSource) (string, error) {
+	token, err := source.Obtain(ctx, &c.initResp)
+	if err != nil {
+		return "", fmt.Errorf("sso: could not obtain SSO token cookie: %w", err)
+	}
+	return token, nil
+}
+
+// Finalize performs the stage-2 "auth-reply" request, exchanging token
+// and opaque for the connection cookie and server fingerprint
+// OpenConnect needs to establish the tunnel. Initialize or Resume must
+// be called first.
+func (c *Client) Finalize(ctx context.Context, token, opaque string) (*FinalResponse, error) {
+	xmlPayload := fmt.Sprintf(`
+    <config-auth client="vpn" type="auth-reply" aggregate-auth-version="2">
+      <version who="vpn">4.7.00136</version>
+      <device-id>linux-64</device-id>
+      <session-token/>
+      <session-id/>
+      <opaque is-for="sg">%s</opaque>
+      <auth>
+        <sso-token>%s</sso-token>
+      </auth>
+      </config-auth>
+  `, opaque, token)
+
+	body, err := c.post(ctx, xmlPayload, c.targetServer)
+	if err != nil {
+		return nil, fmt.Errorf("sso: finalization request failed: %w", err)
+	}
+
+	var result FinalResponse
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("sso: failed to unmarshal finalization response: %w", err)
+	}
+	if result.Cookie == "" || result.Fingerprint == "" {
+		return nil, fmt.Errorf("sso: server rejected the authentication token")
+	}
+
+	return &result, nil
+}
+
+func (c *Client) post(ctx context.Context, xmlPayload, server string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", server, strings.NewReader(xmlPayload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http request: %w", err)
+	}
+	headers := map[string]string{
+		"User-Agent":          "AnyConnect Linux_64 4.7.00136",
+		"Accept":              "*/*",
+		"Accept-Encoding":     "identity",
+		"X-Transcend-Version": "1",
+		"X-Aggregate-Auth":    "1",
+		"X-Support-HTTP-Auth": "true",
+		"Content-Type":        "application/x-www-form-urlencoded",
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to POST request to the server %q: %w", server, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return body, nil
+}
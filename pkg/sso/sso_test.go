@@ -0,0 +1,150 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const initResponseXML = `<?xml version="1.0" encoding="UTF-8"?>
+<config-auth client="vpn" type="auth-request">
+  <auth id="main">
+    <sso-v2-login>https://idp.example.com/login</sso-v2-login>
+    <sso-v2-login-final>https://idp.example.com/login-final</sso-v2-login-final>
+    <sso-v2-token-cookie-name>webvpn</sso-v2-token-cookie-name>
+    <message>Please log in.</message>
+  </auth>
+  <opaque is-for="sg">opaque-value-123</opaque>
+</config-auth>`
+
+const finalResponseXML = `<?xml version="1.0" encoding="UTF-8"?>
+<config-auth client="vpn" type="complete">
+  <auth id="success">
+    <session-token>connection-cookie-abc</session-token>
+  </auth>
+  <config>
+    <vpn-base-config>
+      <server-cert-hash>sha256:deadbeef</server-cert-hash>
+    </vpn-base-config>
+  </config>
+</config-auth>`
+
+// fakeCookieSource is a CookieSource test fake standing in for a
+// browser or headless auth driver.
+type fakeCookieSource struct {
+	token string
+	err   error
+	got   *InitResponse
+}
+
+func (f *fakeCookieSource) Obtain(ctx context.Context, init *InitResponse) (string, error) {
+	f.got = init
+	return f.token, f.err
+}
+
+func newASAServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+		case http.MethodPost:
+			w.Header().Set("Content-Type", "text/xml")
+			if r.Header.Get("X-Aggregate-Auth") != "1" {
+				t.Errorf("missing X-Aggregate-Auth header on POST")
+			}
+			w.Write([]byte(initResponseXML))
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestInitialize(t *testing.T) {
+	server := newASAServer(t)
+	defer server.Close()
+
+	client := NewClient(server.Client())
+	initResp, err := client.Initialize(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if initResp.LoginURL != "https://idp.example.com/login" {
+		t.Errorf("LoginURL = %q, want %q", initResp.LoginURL, "https://idp.example.com/login")
+	}
+	if initResp.Opaque.Value != "opaque-value-123" {
+		t.Errorf("Opaque.Value = %q, want %q", initResp.Opaque.Value, "opaque-value-123")
+	}
+}
+
+func TestWaitForToken(t *testing.T) {
+	server := newASAServer(t)
+	defer server.Close()
+
+	client := NewClient(server.Client())
+	initResp, err := client.Initialize(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	source := &fakeCookieSource{token: "sso-token-xyz"}
+	token, err := client.WaitForToken(context.Background(), source)
+	if err != nil {
+		t.Fatalf("WaitForToken: %v", err)
+	}
+	if token != "sso-token-xyz" {
+		t.Errorf("token = %q, want %q", token, "sso-token-xyz")
+	}
+	if source.got == nil || source.got.LoginURL != initResp.LoginURL {
+		t.Errorf("CookieSource did not receive the InitResponse from Initialize")
+	}
+}
+
+func TestWaitForTokenPropagatesSourceError(t *testing.T) {
+	client := NewClient(http.DefaultClient)
+	source := &fakeCookieSource{err: errors.New("user closed the browser")}
+	if _, err := client.WaitForToken(context.Background(), source); err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(finalResponseXML))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(server.Client())
+	client.Resume(server.URL)
+
+	finalResp, err := client.Finalize(context.Background(), "sso-token-xyz", "opaque-value-123")
+	if err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	if finalResp.Cookie != "connection-cookie-abc" {
+		t.Errorf("Cookie = %q, want %q", finalResp.Cookie, "connection-cookie-abc")
+	}
+	if finalResp.Fingerprint != "sha256:deadbeef" {
+		t.Errorf("Fingerprint = %q, want %q", finalResp.Fingerprint, "sha256:deadbeef")
+	}
+}
+
+func TestFinalizeRejectsIncompleteResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<config-auth client="vpn" type="auth-request"></config-auth>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(server.Client())
+	client.Resume(server.URL)
+
+	if _, err := client.Finalize(context.Background(), "token", "opaque"); err == nil {
+		t.Fatal("expected an error for a response missing cookie/fingerprint, got nil")
+	}
+}
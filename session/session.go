@@ -0,0 +1,259 @@
+// Package session caches SSO tokens between invocations of the tool so
+// that a non-expired session can be reused without driving a browser
+// through the IdP flow again, analogous to how OIDC/VPN tooling caches
+// auth tokens on disk between runs.
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pbkdf2Iterations follows the current OWASP recommendation for
+// PBKDF2-HMAC-SHA256.
+const pbkdf2Iterations = 600000
+
+// saltSize is the size, in bytes, of the random per-file salt stored
+// alongside the ciphertext.
+const saltSize = 16
+
+// ErrNotFound is returned by Store.Load when no cached session exists
+// for the requested server.
+var ErrNotFound = errors.New("session: no cached session for server")
+
+// Session is a single cached SSO outcome for a server.
+type Session struct {
+	// Server is the resolved VPN server URL the session was issued for.
+	Server string `json:"server"`
+	// Token is the SSO token cookie value produced by the browser flow.
+	Token string `json:"token"`
+	// Opaque is the opaque value echoed back during finalization.
+	Opaque    string    `json:"opaque"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the session is past its expiry. A zero
+// ExpiresAt is treated as never-expiring.
+func (s Session) Expired() bool {
+	return !s.ExpiresAt.IsZero() && time.Now().After(s.ExpiresAt)
+}
+
+// DefaultCacheFile returns the default location of the session cache,
+// honouring $XDG_CONFIG_HOME with a fallback to ~/.config.
+func DefaultCacheFile() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not determine home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "go-openconnect-sso", "sessions.enc"), nil
+}
+
+// Store is an encrypted on-disk cache of Sessions, keyed by server URL.
+// The cache file is AES-GCM encrypted with a key derived from a
+// user-supplied passphrase via PBKDF2-HMAC-SHA256, using a random salt
+// stored alongside the ciphertext so that the same passphrase produces a
+// different key in every cache file (and brute-forcing it can't be done
+// once for every user who happens to share a passphrase).
+//
+// A future improvement would be to source the key from the OS keyring
+// instead of a passphrase; that is not implemented here.
+type Store struct {
+	path       string
+	passphrase string
+}
+
+// NewStore opens a Store backed by the file at path, deriving its
+// encryption key from passphrase. It does not touch disk until Load,
+// Save, or Purge is called.
+func NewStore(path, passphrase string) (*Store, error) {
+	if passphrase == "" {
+		return nil, errors.New("session: a passphrase is required to open the cache")
+	}
+	return &Store{path: path, passphrase: passphrase}, nil
+}
+
+// Load returns the cached session for server, or ErrNotFound if there is
+// none.
+func (s *Store) Load(server string) (Session, error) {
+	sessions, err := s.readAll()
+	if err != nil {
+		return Session{}, err
+	}
+	sess, ok := sessions[server]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	return sess, nil
+}
+
+// Save upserts sess into the cache, keyed by sess.Server.
+func (s *Store) Save(sess Session) error {
+	sessions, err := s.readAll()
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if sessions == nil {
+		sessions = map[string]Session{}
+	}
+	sessions[sess.Server] = sess
+	return s.writeAll(sessions)
+}
+
+// Invalidate removes any cached session for server, e.g. after the
+// server rejects it as expired or invalid.
+func (s *Store) Invalidate(server string) error {
+	sessions, err := s.readAll()
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	delete(sessions, server)
+	return s.writeAll(sessions)
+}
+
+// Purge removes the entire cache file.
+func (s *Store) Purge() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("session: could not remove cache file: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) readAll() (map[string]Session, error) {
+	ciphertext, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("session: could not read cache file: %w", err)
+	}
+
+	plaintext, err := s.decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("session: could not decrypt cache file (wrong passphrase?): %w", err)
+	}
+
+	var sessions map[string]Session
+	if err := json.Unmarshal(plaintext, &sessions); err != nil {
+		return nil, fmt.Errorf("session: could not parse cache file: %w", err)
+	}
+	return sessions, nil
+}
+
+func (s *Store) writeAll(sessions map[string]Session) error {
+	plaintext, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("session: could not encode cache: %w", err)
+	}
+
+	ciphertext, err := s.encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("session: could not encrypt cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("session: could not create cache directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, ciphertext, 0600); err != nil {
+		return fmt.Errorf("session: could not write cache file: %w", err)
+	}
+	return nil
+}
+
+// encrypt encrypts plaintext under a fresh random salt, returning
+// salt || nonce || ciphertext.
+func (s *Store) encrypt(plaintext []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	gcm, err := s.gcm(salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return append(salt, sealed...), nil
+}
+
+// decrypt reverses encrypt, reading the salt it stored alongside the
+// nonce and ciphertext.
+func (s *Store) decrypt(data []byte) ([]byte, error) {
+	if len(data) < saltSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	salt, data := data[:saltSize], data[saltSize:]
+
+	gcm, err := s.gcm(salt)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func (s *Store) gcm(salt []byte) (cipher.AEAD, error) {
+	key := pbkdf2Key(s.passphrase, salt, pbkdf2Iterations, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2Key derives an keyLen-byte key from password and salt using
+// PBKDF2-HMAC-SHA256 (RFC 8018), run for iter iterations.
+func pbkdf2Key(password string, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, []byte(password))
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	key := make([]byte, 0, numBlocks*hashLen)
+	buf := make([]byte, 4)
+	for block := 1; block <= numBlocks; block++ {
+		buf[0] = byte(block >> 24)
+		buf[1] = byte(block >> 16)
+		buf[2] = byte(block >> 8)
+		buf[3] = byte(block)
+
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(buf)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(nil)
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
@@ -0,0 +1,154 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.enc")
+	store, err := NewStore(path, "correct-passphrase")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	want := Session{
+		Server:    "https://vpn.example.com",
+		Token:     "tok-123",
+		Opaque:    "opaque-abc",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := store.Load(want.Server)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Token != want.Token || got.Opaque != want.Opaque {
+		t.Fatalf("Load returned %+v, want %+v", got, want)
+	}
+}
+
+func TestStoreLoadMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.enc")
+	store, err := NewStore(path, "correct-passphrase")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+
+	if _, err := store.Load("https://vpn.example.com"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load on empty cache returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.enc")
+	writer, err := NewStore(path, "correct-passphrase")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := writer.Save(Session{Server: "https://vpn.example.com", Token: "tok-123"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reader, err := NewStore(path, "wrong-passphrase")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if _, err := reader.Load("https://vpn.example.com"); err == nil {
+		t.Fatal("Load with wrong passphrase succeeded, want error")
+	}
+}
+
+func TestStoreInvalidateAndPurge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.enc")
+	store, err := NewStore(path, "correct-passphrase")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	server := "https://vpn.example.com"
+	if err := store.Save(Session{Server: server, Token: "tok-123"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if err := store.Invalidate(server); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if _, err := store.Load(server); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load after Invalidate returned %v, want ErrNotFound", err)
+	}
+
+	if err := store.Save(Session{Server: server, Token: "tok-123"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := store.Purge(); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if _, err := store.Load(server); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Load after Purge returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreUsesPerFileSalt(t *testing.T) {
+	pathA := filepath.Join(t.TempDir(), "sessions.enc")
+	pathB := filepath.Join(t.TempDir(), "sessions.enc")
+
+	sess := Session{Server: "https://vpn.example.com", Token: "tok-123"}
+
+	storeA, err := NewStore(pathA, "same-passphrase")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := storeA.Save(sess); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	storeB, err := NewStore(pathB, "same-passphrase")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	if err := storeB.Save(sess); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	ciphertextA, err := os.ReadFile(pathA)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	ciphertextB, err := os.ReadFile(pathB)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	if bytes.Equal(ciphertextA[:saltSize], ciphertextB[:saltSize]) {
+		t.Fatal("two stores with the same passphrase produced the same salt; salt should be random per file")
+	}
+	if bytes.Equal(ciphertextA, ciphertextB) {
+		t.Fatal("two stores with the same passphrase and contents produced identical ciphertext")
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	tests := []struct {
+		name string
+		sess Session
+		want bool
+	}{
+		{"zero expiry never expires", Session{}, false},
+		{"future expiry not expired", Session{ExpiresAt: time.Now().Add(time.Hour)}, false},
+		{"past expiry is expired", Session{ExpiresAt: time.Now().Add(-time.Hour)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sess.Expired(); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// retryingTransport retries requests that fail with a transient network
+// error or a 5xx response, backing off exponentially between attempts.
+type retryingTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+}
+
+func (t *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := bufferBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= t.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(backoff(attempt)):
+			}
+		}
+
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 && attempt < t.maxRetries {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("server returned %s", resp.Status)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("request to %s failed after %d attempts: %w", req.URL, t.maxRetries+1, lastErr)
+}
+
+// bufferBody reads req.Body into memory so it can be replayed on retry;
+// the request bodies this package sends (small XML payloads) are never
+// large enough for this to be a concern.
+func bufferBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("transport: could not buffer request body for retries: %w", err)
+	}
+	req.Body.Close()
+	return body, nil
+}
+
+func backoff(attempt int) time.Duration {
+	return time.Duration(1<<uint(attempt-1)) * 250 * time.Millisecond
+}
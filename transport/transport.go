@@ -0,0 +1,96 @@
+// Package transport builds the HTTP client used to talk to the ASA
+// front-end: proxy-aware, able to trust a custom CA, and retrying
+// transient failures with exponential backoff instead of the caller
+// having to special-case them. A pinned server fingerprint is not part
+// of the client's TLS setup; VerifyFingerprint checks it separately
+// against the fingerprint the server reports.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Options configures the HTTP client returned by NewClient.
+type Options struct {
+	// ProxyURL overrides the proxy to use; when empty, the client falls
+	// back to the standard HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment
+	// variables.
+	ProxyURL string
+	// CAFile is a PEM file of additional CA certificates to trust,
+	// e.g. for a self-signed ASA front-end.
+	CAFile string
+	// Insecure disables TLS certificate verification entirely.
+	Insecure bool
+	// Timeout bounds the entire request, including any retries and the
+	// backoff waits between them.
+	Timeout time.Duration
+	// MaxRetries is how many additional attempts are made after the
+	// first failed one. Retries apply to 5xx responses and transient
+	// network errors, with exponential backoff between attempts.
+	// Negative values are treated as 0.
+	MaxRetries int
+}
+
+// NewClient builds an *http.Client configured per opts.
+func NewClient(opts Options) (*http.Client, error) {
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.Insecure}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("transport: could not read CA file %q: %w", opts.CAFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("transport: no certificates found in CA file %q", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	baseTransport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(opts.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("transport: invalid proxy URL %q: %w", opts.ProxyURL, err)
+		}
+		baseTransport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &http.Client{
+		Transport: &retryingTransport{
+			base:       baseTransport,
+			maxRetries: opts.MaxRetries,
+		},
+		Timeout: opts.Timeout,
+	}, nil
+}
+
+// VerifyFingerprint checks a user-pinned server certificate fingerprint
+// (--server-cert) against the fingerprint the server itself reported in
+// the finalization response, before it is trusted and written to the
+// oc-config file for OpenConnect to use.
+func VerifyFingerprint(pinned, actual string) error {
+	if pinned == "" {
+		return nil
+	}
+	if pinned != actual {
+		return fmt.Errorf("transport: server fingerprint %q does not match pinned fingerprint %q", actual, pinned)
+	}
+	return nil
+}